@@ -0,0 +1,111 @@
+package rocketapi
+
+// Authenticator builds the payload posted to /api/v1/login. Chat calls it
+// once up front via Login, and again automatically whenever a REST call
+// comes back 401, so a session token can expire mid-program without the
+// caller having to notice.
+type Authenticator interface {
+	loginPayload() map[string]interface{}
+}
+
+// PasswordAuth authenticates with a Rocket.Chat username and password. Code
+// is optional and only needed for accounts with two-factor authentication
+// enabled; use WithTOTP to set it just before logging in.
+type PasswordAuth struct {
+	Username string
+	Password string
+	Code     string
+}
+
+func (a PasswordAuth) loginPayload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"username": a.Username,
+		"password": a.Password,
+	}
+	if a.Code != "" {
+		payload["code"] = a.Code
+	}
+	return payload
+}
+
+func (a PasswordAuth) withCode(code string) Authenticator {
+	a.Code = code
+	return a
+}
+
+func (a PasswordAuth) code() string {
+	return a.Code
+}
+
+// PersonalAccessTokenAuth authenticates with a Rocket.Chat personal access
+// token. Rocket.Chat accepts a PAT through the same /api/v1/login endpoint
+// as a password, submitted alongside the username it was issued for.
+type PersonalAccessTokenAuth struct {
+	Username string
+	Token    string
+	Code     string
+}
+
+func (a PersonalAccessTokenAuth) loginPayload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"username": a.Username,
+		"password": a.Token,
+	}
+	if a.Code != "" {
+		payload["code"] = a.Code
+	}
+	return payload
+}
+
+func (a PersonalAccessTokenAuth) withCode(code string) Authenticator {
+	a.Code = code
+	return a
+}
+
+func (a PersonalAccessTokenAuth) code() string {
+	return a.Code
+}
+
+// OAuth2Auth authenticates with an access token already obtained from an
+// external OAuth2 identity provider configured as a Rocket.Chat custom
+// login service.
+type OAuth2Auth struct {
+	ServiceName string
+	AccessToken string
+	ExpiresIn   int64
+}
+
+func (a OAuth2Auth) loginPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"serviceName": a.ServiceName,
+		"accessToken": a.AccessToken,
+		"expiresIn":   a.ExpiresIn,
+	}
+}
+
+// totpAuthenticator is implemented by Authenticators that can carry a TOTP
+// code for two-factor protected accounts.
+type totpAuthenticator interface {
+	withCode(code string) Authenticator
+	code() string
+}
+
+// WithTOTP returns a copy of chat whose Authenticator will submit code as
+// the TOTP token on the next login. It is a no-op for Authenticators that
+// don't support two-factor codes, such as OAuth2Auth.
+//
+// A TOTP code is single-use: Rocket.Chat rejects it once it has already
+// been consumed by a successful login. do's transparent re-login on a 401
+// has no way to obtain a fresh code on its own, so LoginContext refuses to
+// resubmit a code this session has already consumed rather than silently
+// replaying it and failing confusingly against the server. Callers whose
+// Authenticator carries a TOTP code should treat automatic re-auth as
+// unavailable for that account and re-establish the session themselves,
+// via WithTOTP with a newly generated code, once a call fails for that
+// reason.
+func (chat Chat) WithTOTP(code string) Chat {
+	if auth, ok := chat.Authenticator.(totpAuthenticator); ok {
+		chat.Authenticator = auth.withCode(code)
+	}
+	return chat
+}
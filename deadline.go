@@ -0,0 +1,98 @@
+package rocketapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deadline manages a context that is cancelled once a configured point in
+// time is reached, mirroring the setDeadline pattern used by net.Conn
+// implementations: Set can be called at any time, from any goroutine,
+// to push the deadline out or clear it, without racing a request that is
+// already in flight against the previous one.
+type deadline struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	timer  *time.Timer
+}
+
+func newDeadline() *deadline {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &deadline{ctx: ctx, cancel: cancel}
+}
+
+// set installs t as the new deadline. A zero Time clears it. Callers
+// already holding a context derived from the previous deadline (via
+// context) keep running unaffected: set only stops the previous timer so
+// it can't fire later, it never cancels that context itself.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	if t.IsZero() {
+		return
+	}
+	if wait := time.Until(t); wait <= 0 {
+		d.cancel()
+	} else {
+		cancel := d.cancel
+		d.timer = time.AfterFunc(wait, cancel)
+	}
+}
+
+// context snapshots the deadline's current context under lock.
+func (d *deadline) context() context.Context {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ctx
+}
+
+// withDeadline returns a context that is cancelled when either parent is
+// cancelled or d's deadline elapses, along with a cancel func the caller
+// must invoke to release the resources backing it.
+func withDeadline(parent context.Context, d *deadline) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-d.context().Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
+
+// readContext derives ctx so it is also cancelled by SetReadDeadline.
+func (chat Chat) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDeadline(ctx, chat.readDeadline)
+}
+
+// writeContext derives ctx so it is also cancelled by SetWriteDeadline.
+func (chat Chat) writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDeadline(ctx, chat.writeDeadline)
+}
+
+// SetReadDeadline bounds every read-only REST call (history and list
+// lookups) made after it returns; in-flight calls are unaffected. A zero
+// Time removes the deadline.
+func (chat Chat) SetReadDeadline(t time.Time) {
+	chat.readDeadline.set(t)
+}
+
+// SetWriteDeadline bounds every state-changing REST call (posting,
+// editing, reacting, uploading, ...) made after it returns; in-flight
+// calls are unaffected. A zero Time removes the deadline.
+func (chat Chat) SetWriteDeadline(t time.Time) {
+	chat.writeDeadline.set(t)
+}
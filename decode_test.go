@@ -0,0 +1,33 @@
+package rocketapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeErrorResponse(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, `{}`, false},
+		{"badRequest", http.StatusBadRequest, `{"success":false,"error":"boom","errorType":"error-boom"}`, true},
+		{"serverError", http.StatusInternalServerError, `{"success":false,"error":"boom","errorType":"error-boom"}`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := &http.Response{
+				StatusCode: c.status,
+				Body:       io.NopCloser(strings.NewReader(c.body)),
+			}
+			err := decodeErrorResponse(res)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("decodeErrorResponse() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
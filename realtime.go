@@ -0,0 +1,204 @@
+package rocketapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	websocketPath           = "/websocket"
+	roomDiscoveryInterval   = 30 * time.Second
+	subscribeReconnectDelay = 5 * time.Second
+)
+
+// ddpMessage is a loosely typed envelope covering both the DDP messages we
+// send (connect, method, sub, pong) and the ones Rocket.Chat sends back
+// (ping, changed). Fields that don't apply to a given message are left at
+// their zero value and omitted on encode.
+type ddpMessage struct {
+	Msg        string          `json:"msg,omitempty"`
+	ID         string          `json:"id,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Version    string          `json:"version,omitempty"`
+	Support    []string        `json:"support,omitempty"`
+	Method     string          `json:"method,omitempty"`
+	Params     []interface{}   `json:"params,omitempty"`
+	Collection string          `json:"collection,omitempty"`
+	Fields     json.RawMessage `json:"fields,omitempty"`
+}
+
+type streamRoomMessagesFields struct {
+	Args []Message `json:"args"`
+}
+
+// Subscribe opens a DDP/WebSocket connection to Rocket.Chat's realtime API
+// and streams incoming messages for every room (channel or IM) the
+// authenticated user currently belongs to, delivering them with sub-second
+// latency instead of the REST polling done by GetIncomingMessage. It
+// reconnects and re-subscribes automatically on connection loss, picks up
+// newly-joined rooms as they appear, and stops cleanly when ctx is
+// cancelled. GetIncomingMessage remains available as a polling fallback for
+// deployments that can't use websockets.
+func (chat Chat) Subscribe(ctx context.Context) (<-chan Message, error) {
+	wsURL, err := websocketURL(chat.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	msgChan := make(chan Message)
+	go func() {
+		defer close(msgChan)
+		for ctx.Err() == nil {
+			if err := chat.runSubscription(ctx, wsURL, msgChan); err != nil {
+				chat.Logger.Warn(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(subscribeReconnectDelay):
+			}
+		}
+	}()
+
+	return msgChan, nil
+}
+
+func websocketURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + websocketPath
+	return u.String(), nil
+}
+
+// runSubscription performs a single DDP connect/login/sub cycle and blocks,
+// forwarding decoded messages to msgChan, until the connection drops or ctx
+// is cancelled.
+func (chat Chat) runSubscription(ctx context.Context, wsURL string, msgChan chan<- Message) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(ddpMessage{Msg: "connect", Version: "1", Support: []string{"1"}}); err != nil {
+		return err
+	}
+	if err := conn.WriteJSON(ddpMessage{
+		Msg:    "method",
+		Method: "login",
+		ID:     "login",
+		Params: []interface{}{map[string]interface{}{"resume": chat.Token()}},
+	}); err != nil {
+		return err
+	}
+
+	subscribed := make(map[string]bool)
+	nextSubID := 0
+	subscribeRoom := func(roomID string) error {
+		if subscribed[roomID] {
+			return nil
+		}
+		nextSubID++
+		if err := conn.WriteJSON(ddpMessage{
+			Msg:    "sub",
+			ID:     fmt.Sprintf("sub-%d", nextSubID),
+			Name:   "stream-room-messages",
+			Params: []interface{}{roomID, false},
+		}); err != nil {
+			return err
+		}
+		subscribed[roomID] = true
+		return nil
+	}
+
+	current, err := chat.getCurrentRoom(ctx)
+	if err != nil {
+		return err
+	}
+	for _, roomID := range append(append([]string{}, current.channels...), current.ims...) {
+		if err := subscribeRoom(roomID); err != nil {
+			return err
+		}
+	}
+
+	incoming := make(chan ddpMessage)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			var msg ddpMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case incoming <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	roomTicker := time.NewTicker(roomDiscoveryInterval)
+	defer roomTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case msg := <-incoming:
+			switch msg.Msg {
+			case "ping":
+				if err := conn.WriteJSON(ddpMessage{Msg: "pong"}); err != nil {
+					return err
+				}
+			case "changed":
+				if msg.Collection != "stream-room-messages" {
+					continue
+				}
+				var fields streamRoomMessagesFields
+				if err := json.Unmarshal(msg.Fields, &fields); err != nil {
+					chat.Logger.Warn(err)
+					continue
+				}
+				for _, m := range fields.Args {
+					if m.U.ID != chat.UserID() {
+						select {
+						case msgChan <- m:
+						case <-ctx.Done():
+							return nil
+						}
+					}
+				}
+			}
+		case <-roomTicker.C:
+			current, err := chat.getCurrentRoom(ctx)
+			if err != nil {
+				chat.Logger.Warn(err)
+				continue
+			}
+			for _, roomID := range append(append([]string{}, current.channels...), current.ims...) {
+				if err := subscribeRoom(roomID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package rocketapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// newDDPTestServer wires up the REST endpoints runSubscription needs to
+// discover rooms plus a "/websocket" DDP endpoint driven by handleConn.
+func newDDPTestServer(t *testing.T, handleConn func(*testing.T, *websocket.Conn)) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/rooms.get", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(RoomsGetResponse{
+			Status: "success",
+			Update: []struct {
+				ID      string `json:"_id"`
+				Name    string `json:"name"`
+				Default bool   `json:"default"`
+			}{{ID: "room1", Name: "general"}},
+		})
+	})
+	mux.HandleFunc("/api/v1/im.list", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ImList{Success: true})
+	})
+	mux.HandleFunc("/websocket", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		handleConn(t, conn)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// TestSubscribeHandshakeAndMessageDelivery drives a single connect/login/sub
+// cycle against a fake DDP server and checks that a "changed" message
+// posted by a user other than the authenticated one is delivered on
+// Subscribe's channel, and that a server-initiated ping is answered with a
+// pong.
+func TestSubscribeHandshakeAndMessageDelivery(t *testing.T) {
+	done := make(chan struct{})
+	srv := newDDPTestServer(t, func(t *testing.T, conn *websocket.Conn) {
+		defer close(done)
+
+		var connectMsg ddpMessage
+		if err := conn.ReadJSON(&connectMsg); err != nil || connectMsg.Msg != "connect" {
+			t.Errorf("connect message: %+v, err %v", connectMsg, err)
+			return
+		}
+
+		var loginMsg ddpMessage
+		if err := conn.ReadJSON(&loginMsg); err != nil || loginMsg.Method != "login" {
+			t.Errorf("login message: %+v, err %v", loginMsg, err)
+			return
+		}
+		params, _ := loginMsg.Params[0].(map[string]interface{})
+		if params["resume"] != "tok" {
+			t.Errorf("resume token = %v, want %q", params["resume"], "tok")
+		}
+
+		var subMsg ddpMessage
+		if err := conn.ReadJSON(&subMsg); err != nil || subMsg.Name != "stream-room-messages" {
+			t.Errorf("sub message: %+v, err %v", subMsg, err)
+			return
+		}
+
+		if err := conn.WriteJSON(ddpMessage{Msg: "ping"}); err != nil {
+			t.Errorf("write ping: %v", err)
+			return
+		}
+		var pongMsg ddpMessage
+		if err := conn.ReadJSON(&pongMsg); err != nil || pongMsg.Msg != "pong" {
+			t.Errorf("pong message: %+v, err %v", pongMsg, err)
+			return
+		}
+
+		fields, err := json.Marshal(streamRoomMessagesFields{Args: []Message{{
+			ID:  "m1",
+			Msg: "hello",
+			RID: "room1",
+			U: struct {
+				ID       string `json:"_id"`
+				Username string `json:"username"`
+			}{ID: "other-user"},
+		}}})
+		if err != nil {
+			t.Errorf("marshal fields: %v", err)
+			return
+		}
+		if err := conn.WriteJSON(ddpMessage{
+			Msg:        "changed",
+			Collection: "stream-room-messages",
+			Fields:     fields,
+		}); err != nil {
+			t.Errorf("write changed: %v", err)
+			return
+		}
+
+		// Keep the connection open until the test cancels ctx.
+		conn.ReadMessage()
+	})
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chat := NewChat(srv.Client(), srv.URL, nil)
+	chat.session.set("tok", "me")
+
+	msgChan, err := chat.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	select {
+	case msg := <-msgChan:
+		if msg.ID != "m1" || msg.U.ID != "other-user" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for subscribed message")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the DDP connection to close after ctx cancellation")
+	}
+	select {
+	case _, ok := <-msgChan:
+		if ok {
+			t.Fatal("expected msgChan to be closed after ctx cancellation")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for msgChan to close after ctx cancellation")
+	}
+}
@@ -0,0 +1,80 @@
+package rocketapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoRetries429WithRetryAfter(t *testing.T) {
+	var attempts int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/rooms.get", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "update": []interface{}{}})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chat := NewChat(srv.Client(), srv.URL, nil)
+
+	if _, err := chat.GetRoomContext(context.Background()); err != nil {
+		t.Fatalf("GetRoomContext: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+}
+
+// TestDoReauthRespectsCallerContext pins down that do's 401 re-auth uses
+// LoginContext(req.Context()) rather than Login (which would bound itself
+// to context.Background() and ignore the caller's deadline entirely).
+func TestDoReauthRespectsCallerContext(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/login", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "success",
+				"data": map[string]interface{}{
+					"userId":    "u",
+					"authToken": "tok",
+					"me":        map[string]interface{}{"_id": "u"},
+				},
+			})
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/api/v1/rooms.get", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chat := NewChat(srv.Client(), srv.URL, PasswordAuth{Username: "u", Password: "p"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := chat.GetRoomContext(ctx)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("GetRoomContext: expected error from caller's expired context, got nil")
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Fatalf("GetRoomContext blocked %s past the caller's 20ms deadline during re-auth", elapsed)
+	}
+}
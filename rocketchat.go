@@ -5,10 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+const (
+	maxRetries     = 5
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+)
+
 type (
 	userInfoKey string
 	// UserInfo type
@@ -43,22 +52,45 @@ func (d defaultLogger) Warn(v ...interface{}) {
 
 // Chat chat structure.
 type Chat struct {
-	Client  *http.Client
-	BaseURL string
-	Logger  Logger
-	Token   string
-	UserID  string
+	Client        *http.Client
+	BaseURL       string
+	Logger        Logger
+	Authenticator Authenticator
+
+	session       *session
+	readDeadline  *deadline
+	writeDeadline *deadline
 }
 
-// NewChat init new chat.
-func NewChat(client *http.Client, baseURL string) Chat {
+// NewChat init new chat. auth is used by Login and, transparently, to
+// re-authenticate whenever a REST call comes back 401 because the session
+// token has expired.
+func NewChat(client *http.Client, baseURL string, auth Authenticator) Chat {
 	return Chat{
-		Client:  client,
-		BaseURL: baseURL,
-		Logger:  &defaultLogger{},
+		Client:        client,
+		BaseURL:       baseURL,
+		Logger:        &defaultLogger{},
+		Authenticator: auth,
+		session:       newSession(),
+		readDeadline:  newDeadline(),
+		writeDeadline: newDeadline(),
 	}
 }
 
+// Token returns the session token obtained by the most recent Login, or
+// the empty string if Login hasn't succeeded yet.
+func (chat Chat) Token() string {
+	token, _ := chat.session.get()
+	return token
+}
+
+// UserID returns the authenticated user's ID obtained by the most recent
+// Login, or the empty string if Login hasn't succeeded yet.
+func (chat Chat) UserID() string {
+	_, userID := chat.session.get()
+	return userID
+}
+
 // SetLogger set logger
 func (chat Chat) SetLogger(logger Logger) {
 	chat.Logger = logger
@@ -77,22 +109,22 @@ type errorResponse struct {
 
 // PostMessageResponse post message response
 type PostMessageResponse struct {
-	Success bool `json:"success"`
-	Timestamp int64 `json:"ts"`
-	Channel string `json:"channel"`
-	Message struct {
-		Alias string
-		Message string `json:"msg"`
-		ParseURLs bool `json:"parseUrls"`
-		Groupable bool `json:"groupable"`
+	Success   bool   `json:"success"`
+	Timestamp int64  `json:"ts"`
+	Channel   string `json:"channel"`
+	Message   struct {
+		Alias     string
+		Message   string `json:"msg"`
+		ParseURLs bool   `json:"parseUrls"`
+		Groupable bool   `json:"groupable"`
 		Timestamp string `json:"ts"`
-		User struct {
-			ID string `json:"_id"`
+		User      struct {
+			ID       string `json:"_id"`
 			Username string `json:"username"`
 		} `json:"u"`
-		RoomID string `json:"rid"`
+		RoomID    string `json:"rid"`
 		UpdatedAt string `json:"_updatedAt"`
-		ID string `json:"_id"`
+		ID        string `json:"_id"`
 	}
 }
 
@@ -156,17 +188,36 @@ type loginErrResponse struct {
 	Message string `json:"message"`
 }
 
-// Login login on chat
-func (chat *Chat) Login(username, password string) error {
-	body := map[string]string{
-		"username": username,
-		"password": password,
+// Login authenticate chat using its Authenticator and store the resulting
+// session token and user ID.
+func (chat *Chat) Login() error {
+	return chat.LoginContext(context.Background())
+}
+
+// LoginContext is Login with a caller-supplied context, also bounded by
+// SetWriteDeadline. If chat.Authenticator carries a TOTP code already
+// consumed by a previous login on this session, LoginContext fails
+// immediately instead of resubmitting it, since Rocket.Chat would reject
+// the reused one-time code anyway; call WithTOTP with a freshly generated
+// code first.
+func (chat *Chat) LoginContext(ctx context.Context) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	var code string
+	if totp, ok := chat.Authenticator.(totpAuthenticator); ok {
+		code = totp.code()
+		if chat.session.consumedCode(code) {
+			return fmt.Errorf("rocketapi: TOTP code already used for a previous login; call WithTOTP with a fresh code")
+		}
 	}
-	jsonValue, err := json.Marshal(body)
+
+	jsonValue, err := json.Marshal(chat.Authenticator.loginPayload())
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		fmt.Sprintf("%s/api/v1/login", chat.BaseURL),
 		bytes.NewBuffer(jsonValue),
@@ -195,11 +246,118 @@ func (chat *Chat) Login(username, password string) error {
 	if err != nil {
 		return err
 	}
-	chat.UserID = loginResp.Data.UserID
-	chat.Token = loginResp.Data.AuthToken
+	chat.session.set(loginResp.Data.AuthToken, loginResp.Data.UserID)
+	chat.session.markCodeUsed(code)
 	return nil
 }
 
+// do executes req with the current auth headers attached. It transparently
+// re-authenticates via chat.Authenticator and retries once on a 401
+// Unauthorized, and retries with exponential backoff (honoring a
+// Retry-After header when Rocket.Chat sends one) on 429 Too Many Requests,
+// up to maxRetries times. Retries respect req's context, which callers
+// build with readContext/writeContext so a request they bound with
+// SetReadDeadline/SetWriteDeadline doesn't retry past it. Requests whose
+// body can't be replayed (such as the streamed multipart bodies used by
+// UploadFile and SetAvatarFromReader) are never retried: their response
+// is simply returned as-is.
+func (chat *Chat) do(req *http.Request) (*http.Response, error) {
+	backoff := initialBackoff
+	reauthed := false
+	replayable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		chat.applyAuthHeaders(req)
+		res, err := chat.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if replayable && res.StatusCode == http.StatusTooManyRequests && attempt < maxRetries {
+			wait := retryAfter(res, backoff)
+			res.Body.Close()
+			if err := sleepContext(req.Context(), wait); err != nil {
+				return nil, err
+			}
+			backoff = minDuration(backoff*2, maxBackoff)
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if replayable && res.StatusCode == http.StatusUnauthorized && chat.Authenticator != nil && !reauthed {
+			reauthed = true
+			res.Body.Close()
+			if err := chat.LoginContext(req.Context()); err != nil {
+				return nil, err
+			}
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return res, nil
+	}
+}
+
+func (chat *Chat) applyAuthHeaders(req *http.Request) {
+	token, userID := chat.session.get()
+	req.Header.Set("X-Auth-Token", token)
+	req.Header.Set("X-User-id", userID)
+}
+
+// rewind resets req's body to its original contents ahead of a retry.
+func rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// retryAfter picks how long to wait before retrying a 429 response: the
+// server's Retry-After header (seconds or HTTP-date) when present, falling
+// back to backoff otherwise.
+func retryAfter(res *http.Response, backoff time.Duration) time.Duration {
+	h := res.Header.Get("Retry-After")
+	if h == "" {
+		return backoff
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait
+		}
+	}
+	return backoff
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 type logoutResponse struct {
 	Status string `json:"status"`
 	Data   struct {
@@ -209,7 +367,17 @@ type logoutResponse struct {
 
 // Logout Logout of chat
 func (chat *Chat) Logout() error {
-	req, err := http.NewRequest(
+	return chat.LogoutContext(context.Background())
+}
+
+// LogoutContext is Logout with a caller-supplied context, also bounded by
+// SetWriteDeadline.
+func (chat *Chat) LogoutContext(ctx context.Context) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		fmt.Sprintf("%s/api/v1/logout", chat.BaseURL),
 		nil,
@@ -234,8 +402,12 @@ func (chat *Chat) Logout() error {
 	return nil
 }
 
-func (chat Chat) getDiscussion(roomID string) (discussionGetResponse, error) {
-	req, err := http.NewRequest(
+func (chat Chat) getDiscussion(ctx context.Context, roomID string) (discussionGetResponse, error) {
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/chat.getDiscussions?roomId=%s", chat.BaseURL, roomID),
 		nil,
@@ -244,9 +416,7 @@ func (chat Chat) getDiscussion(roomID string) (discussionGetResponse, error) {
 		return discussionGetResponse{}, err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return discussionGetResponse{}, err
 	}
@@ -276,8 +446,8 @@ type currentRoom struct {
 	ims      []string
 }
 
-func (chat Chat) getCurrentRoom() (currentRoom, error) {
-	roomsResp, err := chat.GetRoom()
+func (chat Chat) getCurrentRoom(ctx context.Context) (currentRoom, error) {
+	roomsResp, err := chat.GetRoomContext(ctx)
 	if err != nil {
 		return currentRoom{}, err
 	}
@@ -285,7 +455,7 @@ func (chat Chat) getCurrentRoom() (currentRoom, error) {
 	for _, item := range roomsResp.Update {
 		channels = append(channels, item.ID)
 	}
-	ims, err := chat.GetIMList()
+	ims, err := chat.GetIMListContext(ctx)
 	if err != nil {
 		return currentRoom{}, err
 	}
@@ -303,20 +473,33 @@ func (chat Chat) getCurrentRoom() (currentRoom, error) {
 // GetIncomingMessage Fetch incoming message
 func (chat Chat) GetIncomingMessage(
 	sleepTime time.Duration,
+) <-chan Message {
+	return chat.GetIncomingMessageContext(context.Background(), sleepTime)
+}
+
+// GetIncomingMessageContext is GetIncomingMessage with a caller-supplied
+// context: the polling goroutine stops between iterations and closes the
+// returned channel once ctx is done, instead of running forever.
+func (chat Chat) GetIncomingMessageContext(
+	ctx context.Context,
+	sleepTime time.Duration,
 ) <-chan Message {
 	msgChan := make(chan Message)
 	go func() {
+		defer close(msgChan)
+
 		now := time.Now()
 		lastMessageID := []string{}
 		maxSize := 50
-		for {
-			current, err := chat.getCurrentRoom()
+		for ctx.Err() == nil {
+			current, err := chat.getCurrentRoom(ctx)
 			if err != nil {
 				chat.Logger.Warn(err)
 			}
 			chat.Logger.Debugf("Current room: %v", current)
 			for _, im := range current.ims {
 				resp, err := chat.getIMHistory(
+					ctx,
 					im,
 					"",
 					now.Format(time.RFC3339),
@@ -325,7 +508,7 @@ func (chat Chat) GetIncomingMessage(
 				if err == nil {
 					for _, msg := range resp.Messages {
 						chat.Logger.Debugf("msg: %s", msg)
-						if msg.U.ID != chat.UserID && index(lastMessageID, msg.ID) == -1 {
+						if msg.U.ID != chat.UserID() && index(lastMessageID, msg.ID) == -1 {
 							msgChan <- msg
 							lastMessageID = append(lastMessageID, msg.ID)
 							if len(lastMessageID) > maxSize {
@@ -337,6 +520,7 @@ func (chat Chat) GetIncomingMessage(
 			}
 			for _, channel := range current.channels {
 				resp, err := chat.getChannelsHistory(
+					ctx,
 					channel,
 					"",
 					now.Format(time.RFC3339),
@@ -345,7 +529,7 @@ func (chat Chat) GetIncomingMessage(
 				if err == nil {
 					for _, msg := range resp.Messages {
 						chat.Logger.Debugf("msg: %s", msg)
-						if msg.U.ID != chat.UserID && index(lastMessageID, msg.ID) == -1 {
+						if msg.U.ID != chat.UserID() && index(lastMessageID, msg.ID) == -1 {
 							msgChan <- msg
 							lastMessageID = append(lastMessageID, msg.ID)
 							if len(lastMessageID) > maxSize {
@@ -356,7 +540,11 @@ func (chat Chat) GetIncomingMessage(
 				}
 			}
 			now = time.Now()
-			time.Sleep(sleepTime)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(sleepTime):
+			}
 		}
 	}()
 
@@ -364,12 +552,17 @@ func (chat Chat) GetIncomingMessage(
 }
 
 func (chat Chat) getChannelsHistory(
+	ctx context.Context,
 	roomID string,
 	latest string,
 	oldest string,
 	unreads bool,
 ) (ChannelsHistoryResponse, error) {
-	req, err := http.NewRequest(
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/channels.history", chat.BaseURL),
 		nil,
@@ -392,9 +585,7 @@ func (chat Chat) getChannelsHistory(
 	}
 	req.URL.RawQuery = query.Encode()
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return ChannelsHistoryResponse{}, err
 	}
@@ -428,12 +619,17 @@ func (chat Chat) getChannelsHistory(
 }
 
 func (chat Chat) getIMHistory(
+	ctx context.Context,
 	roomID string,
 	latest string,
 	oldest string,
 	unreads bool,
 ) (ChannelsHistoryResponse, error) {
-	req, err := http.NewRequest(
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/im.history", chat.BaseURL),
 		nil,
@@ -456,9 +652,7 @@ func (chat Chat) getIMHistory(
 	}
 	req.URL.RawQuery = query.Encode()
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return ChannelsHistoryResponse{}, err
 	}
@@ -491,8 +685,12 @@ func (chat Chat) getIMHistory(
 	return channelsHistoryResp, nil
 }
 
-func (chat Chat) getChannels() (getChannelsResponse, error) {
-	req, err := http.NewRequest(
+func (chat Chat) getChannels(ctx context.Context) (getChannelsResponse, error) {
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/channels.list", chat.BaseURL),
 		nil,
@@ -501,9 +699,7 @@ func (chat Chat) getChannels() (getChannelsResponse, error) {
 		return getChannelsResponse{}, err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return getChannelsResponse{}, err
 	}
@@ -521,7 +717,17 @@ func (chat Chat) getChannels() (getChannelsResponse, error) {
 
 // GetRoom get rooms
 func (chat Chat) GetRoom() (RoomsGetResponse, error) {
-	req, err := http.NewRequest(
+	return chat.GetRoomContext(context.Background())
+}
+
+// GetRoomContext is GetRoom with a caller-supplied context, also bounded
+// by SetReadDeadline.
+func (chat Chat) GetRoomContext(ctx context.Context) (RoomsGetResponse, error) {
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/rooms.get", chat.BaseURL),
 		nil,
@@ -530,9 +736,7 @@ func (chat Chat) GetRoom() (RoomsGetResponse, error) {
 		return RoomsGetResponse{}, err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return RoomsGetResponse{}, err
 	}
@@ -550,6 +754,15 @@ func (chat Chat) GetRoom() (RoomsGetResponse, error) {
 
 // SetStatus Set user status
 func (chat Chat) SetStatus(message, status string) error {
+	return chat.SetStatusContext(context.Background(), message, status)
+}
+
+// SetStatusContext is SetStatus with a caller-supplied context, also
+// bounded by SetWriteDeadline.
+func (chat Chat) SetStatusContext(ctx context.Context, message, status string) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
 	body := map[string]string{
 		"message": message,
 		"status":  status,
@@ -558,7 +771,7 @@ func (chat Chat) SetStatus(message, status string) error {
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST",
+	req, err := http.NewRequestWithContext(ctx, "POST",
 		fmt.Sprintf(
 			"%s/api/v1/users.setStatus",
 			chat.BaseURL,
@@ -568,9 +781,7 @@ func (chat Chat) SetStatus(message, status string) error {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	resp, err := chat.Client.Do(req)
+	resp, err := chat.do(req)
 	if err != nil {
 		return err
 	}
@@ -581,11 +792,21 @@ func (chat Chat) SetStatus(message, status string) error {
 
 // PostMessage post message on chat
 func (chat Chat) PostMessage(body map[string]string) (PostMessageResponse, error) {
+	return chat.PostMessageContext(context.Background(), body)
+}
+
+// PostMessageContext is PostMessage with a caller-supplied context, also
+// bounded by SetWriteDeadline.
+func (chat Chat) PostMessageContext(ctx context.Context, body map[string]string) (PostMessageResponse, error) {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
 	jsonValue, err := json.Marshal(body)
 	if err != nil {
 		return PostMessageResponse{}, err
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		fmt.Sprintf("%s/api/v1/chat.postMessage", chat.BaseURL),
 		bytes.NewBuffer(jsonValue),
@@ -593,10 +814,8 @@ func (chat Chat) PostMessage(body map[string]string) (PostMessageResponse, error
 	if err != nil {
 		return PostMessageResponse{}, err
 	}
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
 	req.Header.Add("Content-Type", "application/json")
-	resp, err := chat.Client.Do(req)
+	resp, err := chat.do(req)
 	if err != nil {
 		return PostMessageResponse{}, err
 	}
@@ -615,7 +834,20 @@ func (chat Chat) PostMessage(body map[string]string) (PostMessageResponse, error
 func (chat Chat) GetIMMessages(
 	username string,
 ) (ChannelsHistoryResponse, error) {
-	req, err := http.NewRequest(
+	return chat.GetIMMessagesContext(context.Background(), username)
+}
+
+// GetIMMessagesContext is GetIMMessages with a caller-supplied context,
+// also bounded by SetReadDeadline.
+func (chat Chat) GetIMMessagesContext(
+	ctx context.Context,
+	username string,
+) (ChannelsHistoryResponse, error) {
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/im.messages", chat.BaseURL),
 		nil,
@@ -627,9 +859,7 @@ func (chat Chat) GetIMMessages(
 	query.Add("username", username)
 	req.URL.RawQuery = query.Encode()
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return ChannelsHistoryResponse{}, err
 	}
@@ -673,7 +903,17 @@ type ImList struct {
 
 // GetIMList get im list
 func (chat Chat) GetIMList() (ImList, error) {
-	req, err := http.NewRequest(
+	return chat.GetIMListContext(context.Background())
+}
+
+// GetIMListContext is GetIMList with a caller-supplied context, also
+// bounded by SetReadDeadline.
+func (chat Chat) GetIMListContext(ctx context.Context) (ImList, error) {
+	ctx, cancel := chat.readContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		fmt.Sprintf("%s/api/v1/im.list", chat.BaseURL),
 		nil,
@@ -682,9 +922,7 @@ func (chat Chat) GetIMList() (ImList, error) {
 		return ImList{}, err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Auth-Token", chat.Token)
-	req.Header.Add("X-User-id", chat.UserID)
-	res, err := chat.Client.Do(req)
+	res, err := chat.do(req)
 	if err != nil {
 		return ImList{}, err
 	}
@@ -716,3 +954,286 @@ func (chat Chat) GetIMList() (ImList, error) {
 
 	return channelsHistoryResp, nil
 }
+
+// UpdateMessage edit the text of an existing message.
+func (chat Chat) UpdateMessage(roomID, msgID, text string) error {
+	return chat.UpdateMessageContext(context.Background(), roomID, msgID, text)
+}
+
+// UpdateMessageContext is UpdateMessage with a caller-supplied context,
+// also bounded by SetWriteDeadline.
+func (chat Chat) UpdateMessageContext(ctx context.Context, roomID, msgID, text string) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	body := map[string]string{
+		"roomId": roomID,
+		"msgId":  msgID,
+		"text":   text,
+	}
+	jsonValue, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/chat.update", chat.BaseURL),
+		bytes.NewBuffer(jsonValue),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// DeleteMessage delete a message. asUser deletes the message as the
+// authenticated user rather than as the bot that originally posted it.
+func (chat Chat) DeleteMessage(roomID, msgID string, asUser bool) error {
+	return chat.DeleteMessageContext(context.Background(), roomID, msgID, asUser)
+}
+
+// DeleteMessageContext is DeleteMessage with a caller-supplied context,
+// also bounded by SetWriteDeadline.
+func (chat Chat) DeleteMessageContext(ctx context.Context, roomID, msgID string, asUser bool) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	body := map[string]interface{}{
+		"roomId": roomID,
+		"msgId":  msgID,
+		"asUser": asUser,
+	}
+	jsonValue, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/chat.delete", chat.BaseURL),
+		bytes.NewBuffer(jsonValue),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// React toggle an emoji reaction on a message. shouldReact false removes a
+// reaction previously added with shouldReact true.
+func (chat Chat) React(msgID, emoji string, shouldReact bool) error {
+	return chat.ReactContext(context.Background(), msgID, emoji, shouldReact)
+}
+
+// ReactContext is React with a caller-supplied context, also bounded by
+// SetWriteDeadline.
+func (chat Chat) ReactContext(ctx context.Context, msgID, emoji string, shouldReact bool) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	body := map[string]interface{}{
+		"messageId":   msgID,
+		"emoji":       emoji,
+		"shouldReact": shouldReact,
+	}
+	jsonValue, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/chat.react", chat.BaseURL),
+		bytes.NewBuffer(jsonValue),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// UploadFile uploads a file to roomID, streaming the contents of r through
+// a multipart.Writer rather than buffering the whole payload in memory.
+// msg and description are optional and may be left empty. Because the
+// body is streamed, it can't be replayed: a 401 or 429 encountered
+// mid-upload is returned as-is instead of being retried like other calls.
+func (chat Chat) UploadFile(roomID, filename string, r io.Reader, msg, description string) error {
+	return chat.UploadFileContext(context.Background(), roomID, filename, r, msg, description)
+}
+
+// UploadFileContext is UploadFile with a caller-supplied context, also
+// bounded by SetWriteDeadline.
+func (chat Chat) UploadFileContext(ctx context.Context, roomID, filename string, r io.Reader, msg, description string) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		defer pw.Close()
+
+		part, err := writer.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil && msg != "" {
+			err = writer.WriteField("msg", msg)
+		}
+		if err == nil && description != "" {
+			err = writer.WriteField("description", description)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/rooms.upload/%s", chat.BaseURL, roomID),
+		pr,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// SetAvatar set the authenticated user's avatar from a URL that Rocket.Chat
+// will fetch itself.
+func (chat Chat) SetAvatar(url string) error {
+	return chat.SetAvatarContext(context.Background(), url)
+}
+
+// SetAvatarContext is SetAvatar with a caller-supplied context, also
+// bounded by SetWriteDeadline.
+func (chat Chat) SetAvatarContext(ctx context.Context, url string) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	body := map[string]string{
+		"url": url,
+	}
+	jsonValue, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/users.setAvatar", chat.BaseURL),
+		bytes.NewBuffer(jsonValue),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// SetAvatarFromReader set the authenticated user's avatar by streaming
+// image bytes from r, rather than pointing Rocket.Chat at a URL.
+func (chat Chat) SetAvatarFromReader(r io.Reader) error {
+	return chat.SetAvatarFromReaderContext(context.Background(), r)
+}
+
+// SetAvatarFromReaderContext is SetAvatarFromReader with a caller-supplied
+// context, also bounded by SetWriteDeadline.
+func (chat Chat) SetAvatarFromReaderContext(ctx context.Context, r io.Reader) error {
+	ctx, cancel := chat.writeContext(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		defer pw.Close()
+
+		part, err := writer.CreateFormFile("image", "avatar")
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/api/v1/users.setAvatar", chat.BaseURL),
+		pr,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	res, err := chat.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeErrorResponse(res)
+}
+
+// decodeErrorResponse decodes a Rocket.Chat errorResponse body when res
+// carries a 4xx or 5xx status, returning it as an error; other statuses
+// are treated as success.
+func decodeErrorResponse(res *http.Response) error {
+	if res.StatusCode < 400 {
+		return nil
+	}
+	errorResp := errorResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&errorResp); err != nil {
+		return err
+	}
+	return fmt.Errorf(
+		"status code: %d %s: %s",
+		res.StatusCode,
+		errorResp.ErrorType,
+		errorResp.Error,
+	)
+}
@@ -18,6 +18,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestGetRoom(t *testing.T) {
+	requireLiveServer(t)
 	chat.Logger.Warn("ss")
 	rooms, err := chat.GetRoom()
 	if err != nil {
@@ -29,6 +30,7 @@ func TestGetRoom(t *testing.T) {
 }
 
 func TestGetIMList(t *testing.T) {
+	requireLiveServer(t)
 	imListResp, err := chat.GetIMList()
 	if err != nil {
 		t.Fatal(err)
@@ -39,6 +41,7 @@ func TestGetIMList(t *testing.T) {
 }
 
 func TestSetStatus(t *testing.T) {
+	requireLiveServer(t)
 	err := chat.SetStatus("online", "WAZAAAAAAAA")
 	if err != nil {
 		t.Fatal(err)
@@ -46,6 +49,7 @@ func TestSetStatus(t *testing.T) {
 }
 
 func TestPostMessage(t *testing.T) {
+	requireLiveServer(t)
 	data := map[string]string{
 		"channel": "waza",
 		"text":    "a simple message",
@@ -57,12 +61,56 @@ func TestPostMessage(t *testing.T) {
 	t.Log(res)
 }
 
+func TestUpdateMessage(t *testing.T) {
+	requireLiveServer(t)
+	res, err := chat.PostMessage(map[string]string{
+		"channel": "waza",
+		"text":    "a simple message",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chat.UpdateMessage(res.Message.RoomID, res.Message.ID, "an edited message"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReact(t *testing.T) {
+	requireLiveServer(t)
+	res, err := chat.PostMessage(map[string]string{
+		"channel": "waza",
+		"text":    "a simple message",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chat.React(res.Message.ID, ":thumbsup:", true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// requireLiveServer skips t unless ROCKET_URL is set, so the integration
+// tests in this file don't abort the whole `go test ./...` run (via
+// TestMain) when no live Rocket.Chat server is configured; the unit tests
+// elsewhere in the package (decode_test.go, session_test.go, retry_test.go,
+// totp_test.go, webhook_test.go, realtime_test.go) don't need one and
+// always run.
+func requireLiveServer(t *testing.T) {
+	t.Helper()
+	if os.Getenv("ROCKET_URL") == "" {
+		t.Skip("ROCKET_URL not set; skipping integration test against a live Rocket.Chat server")
+	}
+}
+
 func setup() {
+	baseURL := os.Getenv("ROCKET_URL")
+	if baseURL == "" {
+		return
+	}
 	username := os.Getenv("ROCKET_USERNAME")
 	password := os.Getenv("ROCKET_PASSWORD")
-	baseURL := os.Getenv("ROCKET_URL")
-	chat = NewChat(&http.Client{}, baseURL)
-	if err := chat.Login(username, password); err != nil {
+	chat = NewChat(&http.Client{}, baseURL, PasswordAuth{Username: username, Password: password})
+	if err := chat.Login(); err != nil {
 		panic(err)
 	}
 }
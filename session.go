@@ -0,0 +1,55 @@
+package rocketapi
+
+import "sync"
+
+// session holds the token and user ID Login obtains, behind a pointer, the
+// same way readDeadline/writeDeadline are: almost every Chat method has a
+// value receiver, so a plain string field refreshed by Login through one
+// copy would never be visible through any other copy of Chat sharing the
+// same underlying connection (such as the one returned by NewChat). Storing
+// the mutable state behind a pointer makes a refreshed token visible
+// everywhere, regardless of which copy of Chat triggered the refresh.
+type session struct {
+	mu       sync.Mutex
+	token    string
+	userID   string
+	usedCode string
+}
+
+func newSession() *session {
+	return &session{}
+}
+
+// set installs token and userID as the session's current credentials.
+func (s *session) set(token, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	s.userID = userID
+}
+
+// get returns the session's current credentials.
+func (s *session) get() (token, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, s.userID
+}
+
+// consumedCode reports whether code was already used by a previous
+// successful login through this session, such as a TOTP code spent by an
+// earlier transparent re-auth.
+func (s *session) consumedCode(code string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return code != "" && code == s.usedCode
+}
+
+// markCodeUsed records code as consumed once the login that submitted it
+// has succeeded.
+func (s *session) markCodeUsed(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code != "" {
+		s.usedCode = code
+	}
+}
@@ -0,0 +1,69 @@
+package rocketapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSessionSharedAcrossValueReceiverCopies(t *testing.T) {
+	chat := NewChat(http.DefaultClient, "", PasswordAuth{})
+	cpy := chat // the kind of copy every value-receiver REST method takes
+
+	chat.session.set("tok", "uid")
+
+	if got := cpy.Token(); got != "tok" {
+		t.Fatalf("cpy.Token() = %q, want %q", got, "tok")
+	}
+	if got := cpy.UserID(); got != "uid" {
+		t.Fatalf("cpy.UserID() = %q, want %q", got, "uid")
+	}
+}
+
+// TestReauthPersistsAcrossValueReceiverCalls exercises the bug the
+// session pointer fixes end to end: GetRoomContext has a value receiver,
+// so it runs against a copy of Chat, and the 401 it gets back is handled
+// several value-receiver copies deep inside do(). The refreshed token
+// must still be visible on the original chat once the call returns.
+func TestReauthPersistsAcrossValueReceiverCalls(t *testing.T) {
+	var reauths, roomCalls int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/login", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&reauths, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"userId":    "u2",
+				"authToken": "tok-2",
+				"me":        map[string]interface{}{"_id": "u2"},
+			},
+		})
+		_ = n
+	})
+	mux.HandleFunc("/api/v1/rooms.get", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&roomCalls, 1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "update": []interface{}{}})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chat := NewChat(srv.Client(), srv.URL, PasswordAuth{Username: "u", Password: "p"})
+
+	if _, err := chat.GetRoomContext(context.Background()); err != nil {
+		t.Fatalf("GetRoomContext: %v", err)
+	}
+	if got := chat.Token(); got != "tok-2" {
+		t.Fatalf("chat.Token() = %q, want %q (refreshed token must survive the value-receiver call chain)", got, "tok-2")
+	}
+	if atomic.LoadInt32(&reauths) != 1 {
+		t.Fatalf("reauths = %d, want 1", reauths)
+	}
+}
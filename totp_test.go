@@ -0,0 +1,57 @@
+package rocketapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestLoginContextRejectsReusedTOTPCode pins down that a transparent
+// re-login (or any repeat Login) doesn't silently resubmit a TOTP code
+// this session already spent: Rocket.Chat would reject it anyway, and a
+// stale 401 could otherwise send the caller into a confusing failure loop.
+func TestLoginContextRejectsReusedTOTPCode(t *testing.T) {
+	var logins int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/login", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&logins, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"userId":    "u",
+				"authToken": "tok",
+				"me":        map[string]interface{}{"_id": "u"},
+			},
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chat := NewChat(srv.Client(), srv.URL, PasswordAuth{Username: "u", Password: "p"}).WithTOTP("123456")
+
+	if err := chat.Login(); err != nil {
+		t.Fatalf("first Login: %v", err)
+	}
+	if got := atomic.LoadInt32(&logins); got != 1 {
+		t.Fatalf("logins after first call = %d, want 1", got)
+	}
+
+	if err := chat.Login(); err == nil {
+		t.Fatal("second Login with the same TOTP code: expected an error, got nil")
+	}
+	if got := atomic.LoadInt32(&logins); got != 1 {
+		t.Fatalf("logins after rejected reuse = %d, want 1 (no request should have been sent)", got)
+	}
+
+	chat = chat.WithTOTP("654321")
+	if err := chat.Login(); err != nil {
+		t.Fatalf("Login with a fresh TOTP code: %v", err)
+	}
+	if got := atomic.LoadInt32(&logins); got != 2 {
+		t.Fatalf("logins after fresh code = %d, want 2", got)
+	}
+}
@@ -0,0 +1,115 @@
+package rocketapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WebhookMessage is the payload Rocket.Chat posts to an outgoing webhook
+// integration for every message in the rooms it watches.
+type WebhookMessage struct {
+	MessageID   string `json:"message_id"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	Text        string `json:"text"`
+	Timestamp   string `json:"timestamp"`
+	Token       string `json:"token"`
+}
+
+// webhookHandler builds the HandlerFunc ListenWebhookContext registers:
+// every request is checked against secret (matched against either the
+// X-Rocketchat-Token header or the payload's own "token" field, whichever
+// Rocket.Chat was configured to send, using a constant-time comparison
+// since secret is a shared credential) and, once verified, translated
+// into a Message delivered on msgChan. Split out from
+// ListenWebhookContext so it can be exercised with httptest without
+// binding a real socket.
+func webhookHandler(secret string, msgChan chan<- Message) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload := WebhookMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		token := r.Header.Get("X-Rocketchat-Token")
+		if token == "" {
+			token = payload.Token
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		msgChan <- Message{
+			ID:  payload.MessageID,
+			Msg: payload.Text,
+			Ts:  payload.Timestamp,
+			RID: payload.ChannelID,
+			U: struct {
+				ID       string `json:"_id"`
+				Username string `json:"username"`
+			}{
+				ID:       payload.UserID,
+				Username: payload.UserName,
+			},
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ListenWebhook starts an HTTP server on addr and serves path as a
+// Rocket.Chat outgoing-webhook receiver, running until the process exits.
+// This lets a bot receive messages from behind NAT without long-polling or
+// an authenticated session for inbound traffic; outbound replies still go
+// through the existing REST methods such as PostMessage and SetStatus.
+func (chat Chat) ListenWebhook(addr, path, secret string) (<-chan Message, error) {
+	return chat.ListenWebhookContext(context.Background(), addr, path, secret)
+}
+
+// ListenWebhookContext is ListenWebhook with a caller-supplied context:
+// once ctx is done, the server is gracefully shut down and the returned
+// channel is closed, instead of holding the listening socket open forever.
+// The socket is bound synchronously before this returns, so a bind failure
+// (such as addr already being in use) is returned directly instead of only
+// reaching the Logger.
+func (chat Chat) ListenWebhookContext(ctx context.Context, addr, path, secret string) (<-chan Message, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	msgChan := make(chan Message)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, webhookHandler(secret, msgChan))
+
+	server := &http.Server{
+		Handler: mux,
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			chat.Logger.Warn(fmt.Errorf("webhook server: %w", err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			chat.Logger.Warn(fmt.Errorf("webhook server shutdown: %w", err))
+		}
+		close(msgChan)
+	}()
+
+	return msgChan, nil
+}
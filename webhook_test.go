@@ -0,0 +1,68 @@
+package rocketapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandlerValidToken(t *testing.T) {
+	msgChan := make(chan Message, 1)
+	handler := webhookHandler("s3cr3t", msgChan)
+
+	body := `{"message_id":"m1","channel_id":"c1","user_id":"u1","user_name":"alice","text":"hi","timestamp":"123"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("X-Rocketchat-Token", "s3cr3t")
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	msg := <-msgChan
+	if msg.ID != "m1" || msg.Msg != "hi" || msg.RID != "c1" || msg.U.ID != "u1" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestWebhookHandlerInvalidToken(t *testing.T) {
+	msgChan := make(chan Message, 1)
+	handler := webhookHandler("s3cr3t", msgChan)
+
+	body := `{"message_id":"m1","token":"wrong"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	select {
+	case msg := <-msgChan:
+		t.Fatalf("unexpected message delivered for invalid token: %+v", msg)
+	default:
+	}
+}
+
+// TestListenWebhookContextBindFailure checks that a bind failure on addr
+// (e.g. it's already in use) is returned synchronously from
+// ListenWebhookContext, instead of only reaching the Logger from inside a
+// detached goroutine.
+func TestListenWebhookContextBindFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer ln.Close()
+
+	var chat Chat
+	_, err = chat.ListenWebhookContext(context.Background(), ln.Addr().String(), "/webhook", "secret")
+	if err == nil {
+		t.Fatal("ListenWebhookContext: expected bind error for an address already in use, got nil")
+	}
+}